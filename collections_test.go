@@ -0,0 +1,71 @@
+package ottomatic
+
+import (
+	"testing"
+
+	"github.com/robertkrimen/otto"
+)
+
+type Tag struct {
+	Name string `otto:"name"`
+}
+
+type Tagged struct {
+	Tags    []Tag          `otto:"tags"`
+	Empty   []Tag          `otto:"empty,omitempty"`
+	Raw     []Tag          `otto:"raw,elem=raw"`
+	Numbers []int          `otto:"numbers"`
+	Props   map[string]int `otto:"props"`
+}
+
+func TestRegister_Slice(t *testing.T) {
+	o := otto.New()
+	v := &Tagged{
+		Tags:    []Tag{{Name: "a"}, {Name: "b"}},
+		Numbers: []int{1, 2, 3},
+		Props:   map[string]int{"x": 1, "y": 2},
+	}
+
+	if err := Register("top", v, o); err != nil {
+		t.Fatal(err)
+	}
+
+	if res, err := DeepGet("top.tags.1.name", o); err != nil {
+		t.Fatal(err)
+	} else if s, _ := res.ToString(); s != "b" {
+		t.Errorf("Expected \"b\", got %q", s)
+	}
+
+	if res, err := DeepGet("top.numbers.2", o); err != nil {
+		t.Fatal(err)
+	} else if n, _ := res.ToInteger(); n != 3 {
+		t.Errorf("Expected 3, got %d", n)
+	}
+
+	if res, err := DeepGet("top.props.x", o); err != nil {
+		t.Fatal(err)
+	} else if n, _ := res.ToInteger(); n != 1 {
+		t.Errorf("Expected 1, got %d", n)
+	}
+
+	// omitempty: an empty slice should not create a key at all.
+	if _, err := DeepGet("top.empty", o); err == nil {
+		t.Error("Expected top.empty to be undefined")
+	}
+
+	// elem=raw: the slice is set as a single opaque value rather than a JS
+	// array of bound structs, and the key is still defined.
+	if res, err := DeepGet("top.raw", o); err != nil {
+		t.Fatal(err)
+	} else if res.IsUndefined() {
+		t.Error("Expected top.raw to be defined")
+	}
+}
+
+func TestRegister_MapBadKey(t *testing.T) {
+	o := otto.New()
+	err := Register("bad", map[int]string{1: "one"}, o)
+	if err == nil {
+		t.Error("Expected an error registering a non-string-keyed map")
+	}
+}
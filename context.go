@@ -0,0 +1,177 @@
+package ottomatic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/robertkrimen/otto"
+)
+
+// ErrBudgetExceeded is the error RunContext and CallContext report when a
+// script is interrupted because it exceeded a budget installed with
+// WithBudget.
+var ErrBudgetExceeded = errors.New("ottomatic: script exceeded its operation budget")
+
+// interruptPanic is the sentinel panic value used to recognize our own
+// vm.Interrupt functions (as opposed to some other panic originating from
+// within the running script) when it is recovered by RunContext or
+// CallContext.
+type interruptPanic struct{ err error }
+
+// interruptLocks guards vm.Interrupt for each *otto.Otto that watchInterrupt
+// or WithBudget touches. vm.Interrupt is a plain exported field with no
+// synchronization of its own, yet watchInterrupt swaps it in and out for the
+// duration of a single RunContext/CallContext call while WithBudget's ticker
+// goroutine may be reading it at the same time to deliver a budget
+// interrupt; both sides must go through interruptLockFor(vm) before touching
+// the field so neither races the other nor sends on a channel that's
+// mid-swap.
+var interruptLocks sync.Map // map[*otto.Otto]*sync.Mutex
+
+func interruptLockFor(vm *otto.Otto) *sync.Mutex {
+	actual, _ := interruptLocks.LoadOrStore(vm, &sync.Mutex{})
+	return actual.(*sync.Mutex)
+}
+
+// RunContext runs src in vm, the same way vm.Run would, except that
+// cancelling ctx (or ctx's deadline elapsing) interrupts the running
+// script and causes RunContext to return ctx.Err() instead of whatever
+// otto would otherwise report.
+//
+// RunContext installs its own vm.Interrupt channel for the duration of the
+// call and restores whatever was there beforehand when it returns, so it
+// must not be called for the same vm from more than one goroutine at a
+// time.
+func RunContext(ctx context.Context, vm *otto.Otto, src interface{}) (otto.Value, error) {
+	return watchInterrupt(ctx, vm, func() (otto.Value, error) {
+		return vm.Run(src)
+	})
+}
+
+// CallContext calls fn in vm with the given this and arguments, the same
+// way otto.Value.Call would, except that cancelling ctx interrupts the
+// call and causes CallContext to return ctx.Err().
+//
+// Like RunContext, CallContext installs its own vm.Interrupt channel for
+// the duration of the call, so it must not be called for the same vm from
+// more than one goroutine at a time.
+func CallContext(ctx context.Context, vm *otto.Otto, fn otto.Value, this interface{}, args ...interface{}) (otto.Value, error) {
+	return watchInterrupt(ctx, vm, func() (otto.Value, error) {
+		thisVal, err := vm.ToValue(this)
+		if err != nil {
+			return otto.UndefinedValue(), err
+		}
+		return fn.Call(thisVal, args...)
+	})
+}
+
+// watchInterrupt runs do while watching ctx, translating an interruption
+// triggered by either ctx's cancellation or a panic installed by
+// WithBudget into a returned error instead of a panic.
+func watchInterrupt(ctx context.Context, vm *otto.Otto, do func() (otto.Value, error)) (result otto.Value, err error) {
+	done := make(chan struct{})
+	defer close(done)
+
+	lock := interruptLockFor(vm)
+
+	lock.Lock()
+	prev := vm.Interrupt
+	interrupt := make(chan func(), 1)
+	vm.Interrupt = interrupt
+	lock.Unlock()
+
+	defer func() {
+		lock.Lock()
+		vm.Interrupt = prev
+		lock.Unlock()
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			select {
+			case interrupt <- func() { panic(interruptPanic{ctx.Err()}) }:
+			default:
+			}
+		case <-done:
+		}
+	}()
+
+	defer func() {
+		if caught := recover(); caught != nil {
+			if ip, ok := caught.(interruptPanic); ok {
+				result, err = otto.UndefinedValue(), ip.err
+				return
+			}
+			panic(caught)
+		}
+	}()
+
+	return do()
+}
+
+// WithBudget installs a coarse instruction-count heuristic on vm: a script
+// running in vm is interrupted, with ErrBudgetExceeded, once it has run
+// for roughly ops operations. WithBudget returns a cancel function that
+// stops the budget timer; call it once vm is no longer in use (for
+// example, once the script that prompted the budget has finished).
+//
+// otto has no native instruction counter, so this is necessarily an
+// approximation: WithBudget ticks a time.Ticker at a fixed rate and
+// assumes a fixed number of VM operations per tick, interrupting once
+// enough ticks have elapsed to account for ops. It is meant to guard
+// against runaway scripts (for example, an infinite loop in untrusted
+// input), not to provide an exact operation count.
+//
+// WithBudget composes with RunContext and CallContext: run the budgeted
+// script through one of those, and the interruption this installs comes
+// back as ErrBudgetExceeded rather than as a panic.
+func WithBudget(vm *otto.Otto, ops int) (cancel func()) {
+	const (
+		opsPerTick = 10000
+		tickEvery  = time.Millisecond
+	)
+
+	ticks := ops / opsPerTick
+	if ticks < 1 {
+		ticks = 1
+	}
+
+	lock := interruptLockFor(vm)
+	lock.Lock()
+	if vm.Interrupt == nil {
+		vm.Interrupt = make(chan func(), 1)
+	}
+	lock.Unlock()
+
+	ticker := time.NewTicker(tickEvery)
+	stop := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+		count := 0
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				count++
+				if count < ticks {
+					continue
+				}
+				lock.Lock()
+				interrupt := vm.Interrupt
+				lock.Unlock()
+				select {
+				case interrupt <- func() { panic(interruptPanic{ErrBudgetExceeded}) }:
+				default:
+				}
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
@@ -0,0 +1,99 @@
+package ottomatic
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robertkrimen/otto"
+)
+
+func TestRunContext_Cancel(t *testing.T) {
+	o := otto.New()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := RunContext(ctx, o, `while (true) {}`)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRunContext_Completes(t *testing.T) {
+	o := otto.New()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	v, err := RunContext(ctx, o, `1 + 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := v.ToInteger(); n != 2 {
+		t.Errorf("Expected 2, got %d", n)
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	o := otto.New()
+	fn, err := o.Run(`(function(a, b){ return a + b; })`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	v, err := CallContext(ctx, o, fn, nil, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := v.ToInteger(); n != 5 {
+		t.Errorf("Expected 5, got %d", n)
+	}
+}
+
+func TestWithBudget(t *testing.T) {
+	o := otto.New()
+	cancel := WithBudget(o, 1)
+	defer cancel()
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelCtx()
+
+	_, err := RunContext(ctx, o, `while (true) {}`)
+	if err != ErrBudgetExceeded {
+		t.Errorf("Expected ErrBudgetExceeded, got %v", err)
+	}
+}
+
+// TestWithBudget_Race is a regression test for a data race between
+// WithBudget's ticker goroutine (which reads vm.Interrupt to deliver its
+// interrupt) and RunContext's watchInterrupt (which swaps vm.Interrupt out
+// and back in for the duration of a call). Driving many (vm, budget)
+// pairs concurrently exercises that composition, and the shared lock
+// registry it runs through, under contention; run this test with -race to
+// catch a regression.
+func TestWithBudget_Race(t *testing.T) {
+	const n = 8
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+
+			o := otto.New()
+			cancel := WithBudget(o, 1)
+			defer cancel()
+
+			ctx, cancelCtx := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancelCtx()
+
+			if _, err := RunContext(ctx, o, `while (true) {}`); err != ErrBudgetExceeded {
+				t.Errorf("Expected ErrBudgetExceeded, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,150 @@
+package ottomatic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robertkrimen/otto"
+)
+
+// ObjectAccessor can both get and set values on a JavaScript object.
+type ObjectAccessor interface {
+	ObjectGetter
+	ObjectSetter
+}
+
+// DeepSetOptions controls the behavior of DeepSet.
+type DeepSetOptions struct {
+	// CreateMissing, when true (the default), causes DeepSet to create an
+	// empty object ({}) for any undefined intermediate segment in the
+	// dotted path. When false, DeepSet returns an ErrUndefined for a
+	// missing intermediate segment instead of creating one.
+	CreateMissing bool
+}
+
+// DeepSet sets a value at a dotted key path inside o, creating intermediate
+// objects as necessary.
+//
+// Like DeepGet, key may use JavaScript dotted notation
+// ('parent.child.grandchild') to specify the target. Unlike DeepGet, an
+// undefined intermediate segment is not necessarily an error: by default,
+// DeepSet creates an empty object ({}) for each undefined segment it
+// encounters so that the final key can be set. Pass a DeepSetOptions with
+// CreateMissing: false to get ErrUndefined instead.
+//
+// The terminal value v is bound with RegisterTo, so struct values are bound
+// according to their 'otto' tags exactly as they would be by Register.
+func DeepSet(key string, v interface{}, o *otto.Otto, opts ...DeepSetOptions) error {
+	opt := DeepSetOptions{CreateMissing: true}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	keys := strings.Split(key, ".")
+	parent, err := deepParent(keys[:len(keys)-1], o, opt)
+	if err != nil {
+		return err
+	}
+	return RegisterTo(keys[len(keys)-1], v, o, parent)
+}
+
+// deepParent walks keys from o, creating intermediate objects along the way
+// when opt.CreateMissing is true, and returns the object the final key in
+// the original path should be set on.
+func deepParent(keys []string, o *otto.Otto, opt DeepSetOptions) (ObjectAccessor, error) {
+	var cur ObjectAccessor = o
+	for _, k := range keys {
+		v, err := cur.Get(k)
+		if err != nil {
+			return nil, err
+		}
+		if v.IsUndefined() {
+			if !opt.CreateMissing {
+				return nil, ErrUndefined(k)
+			}
+			created, err := o.Object("({})")
+			if err != nil {
+				return nil, err
+			}
+			if err := cur.Set(k, created); err != nil {
+				return nil, err
+			}
+			cur = created
+			continue
+		}
+		obj := v.Object()
+		if obj == nil {
+			return nil, fmt.Errorf("%q is not an object", k)
+		}
+		cur = obj
+	}
+	return cur, nil
+}
+
+// DeepCall resolves a function at a dotted key path and calls it with this
+// and args.
+//
+// key is resolved exactly as DeepGet resolves it. An ErrUndefined is
+// returned if any segment of key is undefined, and a plain error is
+// returned if the resolved value is not callable.
+func DeepCall(key string, this interface{}, o *otto.Otto, args ...interface{}) (otto.Value, error) {
+	fn, err := DeepGet(key, o)
+	if err != nil {
+		return fn, err
+	}
+	if !fn.IsFunction() {
+		return otto.UndefinedValue(), fmt.Errorf("%q is not a function", key)
+	}
+
+	thisVal, err := o.ToValue(this)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	return fn.Call(thisVal, args...)
+}
+
+// DeepDelete deletes the value at a dotted key path inside o, using
+// JavaScript's own delete operator.
+//
+// Unlike DeepGet and DeepSet, DeepDelete does not error when an
+// intermediate or terminal segment is already undefined; deleting a
+// nonexistent key is simply a no-op, matching JavaScript's own semantics.
+//
+// key is resolved exactly as DeepGet resolves it, so it is never
+// interpolated into a script: the terminal segment is deleted through
+// otto's Object/Value API with the key passed as a plain argument, never as
+// a fragment of JavaScript source.
+func DeepDelete(key string, o *otto.Otto) error {
+	if strings.TrimSpace(key) == "" {
+		return errors.New("empty key")
+	}
+
+	keys := strings.Split(key, ".")
+	last := keys[len(keys)-1]
+
+	var parent otto.Value
+	if parentKeys := keys[:len(keys)-1]; len(parentKeys) == 0 {
+		global, err := o.Object("this")
+		if err != nil {
+			return err
+		}
+		parent = global.Value()
+	} else {
+		v, err := DeepGet(strings.Join(parentKeys, "."), o)
+		if err != nil {
+			if _, ok := err.(ErrUndefined); ok {
+				return nil
+			}
+			return err
+		}
+		parent = v
+	}
+
+	del, err := o.Run(`(function(obj, key) { delete obj[key]; })`)
+	if err != nil {
+		return err
+	}
+	_, err = del.Call(otto.UndefinedValue(), parent, last)
+	return err
+}
@@ -0,0 +1,105 @@
+package ottomatic
+
+import (
+	"testing"
+
+	"github.com/robertkrimen/otto"
+)
+
+func TestDeepSet(t *testing.T) {
+	o := otto.New()
+
+	if err := DeepSet("parent.child.grandchild", "hello", o); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := DeepGet("parent.child.grandchild", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := v.ToString(); err != nil || s != "hello" {
+		t.Errorf("Expected \"hello\", got %q (%s)", s, err)
+	}
+
+	// A second DeepSet on an existing intermediate object must not clobber
+	// its siblings.
+	if err := DeepSet("parent.child.other", 42, o); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := DeepGet("parent.child.grandchild", o); err != nil || mustString(t, v) != "hello" {
+		t.Errorf("Expected sibling grandchild to survive, got %v (%s)", v, err)
+	}
+
+	if err := DeepSet("parent.child.grandchild", "world", o, DeepSetOptions{CreateMissing: false}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeepSet("nosuchparent.child", 1, o, DeepSetOptions{CreateMissing: false}); err == nil {
+		t.Error("Expected missing intermediate to error when CreateMissing is false")
+	}
+}
+
+func mustString(t *testing.T, v otto.Value) string {
+	t.Helper()
+	s, err := v.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestDeepCall(t *testing.T) {
+	o := otto.New()
+	if _, err := o.Run(`parent = { child: { greet: function(name){ return "hi " + name; } } };`); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := DeepCall("parent.child.greet", nil, o, "world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := mustString(t, v); s != "hi world" {
+		t.Errorf("Expected \"hi world\", got %q", s)
+	}
+
+	if _, err := DeepCall("parent.child", nil, o); err == nil {
+		t.Error("Expected error calling a non-function")
+	}
+}
+
+func TestDeepDelete(t *testing.T) {
+	o := otto.New()
+	if _, err := o.Run(`parent = { child: "bye" };`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeepDelete("parent.child", o); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := DeepGet("parent.child", o); err == nil {
+		t.Errorf("Expected parent.child to be undefined, got %v", v)
+	}
+
+	// Deleting an already-undefined key is a no-op, not an error.
+	if err := DeepDelete("parent.nosuchkey", o); err != nil {
+		t.Errorf("Expected no error deleting a nonexistent key, got %s", err)
+	}
+
+	// A missing intermediate segment is also a no-op, not an error.
+	if err := DeepDelete("nosuchparent.child", o); err != nil {
+		t.Errorf("Expected no error deleting under a nonexistent parent, got %s", err)
+	}
+
+	// key is never interpolated into a script: a key containing characters
+	// that would be meaningful as JavaScript source must not be executed.
+	if err := o.Set("hit", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeepDelete("parent.child; hit = true", o); err != nil {
+		t.Fatal(err)
+	}
+	if hit, err := o.Get("hit"); err != nil || mustString(t, hit) != "false" {
+		t.Errorf("Expected \"hit\" to be untouched, got %v (%s)", hit, err)
+	}
+}
@@ -0,0 +1,245 @@
+package ottomatic
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/robertkrimen/otto"
+)
+
+// Loop is a minimal event loop that drives JavaScript timers (setTimeout,
+// setInterval, and setImmediate) from Go.
+//
+// A Loop owns a heap of pending tasks ordered by deadline. Run pops the
+// earliest task, waits for its deadline, and invokes its callback. Every
+// callback is invoked from the single goroutine that called Run, so the
+// bound *otto.Otto is never touched concurrently.
+//
+// l.mu guards l.tasks, l.byID, and l.nextID, since Defer (and therefore
+// schedule and Cancel, which share the same bookkeeping) is documented to be
+// safe to call from other goroutines. It is always released before Run
+// invokes a task's callback, so a vm.Call never happens while l.mu is held.
+//
+// The zero value is not usable; create a Loop with NewLoop.
+type Loop struct {
+	vm     *otto.Otto
+	mu     sync.Mutex
+	tasks  taskHeap
+	byID   map[int64]*task
+	nextID int64
+	wake   chan struct{}
+}
+
+type task struct {
+	id        int64
+	deadline  time.Time
+	interval  time.Duration // zero for a one-shot task (setTimeout/setImmediate)
+	callback  otto.Value
+	args      []otto.Value
+	goFunc    func() // set instead of callback for Defer-scheduled tasks
+	cancelled bool
+}
+
+// taskHeap orders tasks by deadline for use with container/heap.
+type taskHeap []*task
+
+func (h taskHeap) Len() int            { return len(h) }
+func (h taskHeap) Less(i, j int) bool  { return h[i].deadline.Before(h[j].deadline) }
+func (h taskHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap) Push(x interface{}) { *h = append(*h, x.(*task)) }
+func (h *taskHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	*h = old[:n-1]
+	return t
+}
+
+// NewLoop creates a new, empty Loop.
+func NewLoop() *Loop {
+	return &Loop{
+		byID: map[int64]*task{},
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Register installs setTimeout, setInterval, setImmediate, clearTimeout, and
+// clearInterval into vm, backed by l.
+//
+// Register must be called before Run. The timers it installs do not fire
+// until Run is driven from somewhere (typically a goroutine dedicated to
+// this vm).
+func (l *Loop) Register(vm *otto.Otto) error {
+	l.vm = vm
+	vm.Set("setTimeout", l.setTimeout)
+	vm.Set("setInterval", l.setInterval)
+	vm.Set("setImmediate", l.setImmediate)
+	vm.Set("clearTimeout", l.clear)
+	vm.Set("clearInterval", l.clear)
+	return nil
+}
+
+func (l *Loop) setTimeout(call otto.FunctionCall) otto.Value {
+	return l.schedule(call, argDuration(call, 1), 0)
+}
+
+func (l *Loop) setInterval(call otto.FunctionCall) otto.Value {
+	d := argDuration(call, 1)
+	return l.schedule(call, d, d)
+}
+
+func (l *Loop) setImmediate(call otto.FunctionCall) otto.Value {
+	return l.schedule(call, 0, 0)
+}
+
+func (l *Loop) clear(call otto.FunctionCall) otto.Value {
+	if id, err := call.Argument(0).ToInteger(); err == nil {
+		l.Cancel(id)
+	}
+	return otto.UndefinedValue()
+}
+
+func argDuration(call otto.FunctionCall, index int) time.Duration {
+	ms, err := call.Argument(index).ToInteger()
+	if err != nil || ms < 0 {
+		ms = 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+func (l *Loop) schedule(call otto.FunctionCall, delay, interval time.Duration) otto.Value {
+	cb := call.Argument(0)
+	if !cb.IsFunction() {
+		return otto.UndefinedValue()
+	}
+	var extra []otto.Value
+	if len(call.ArgumentList) > 2 {
+		extra = call.ArgumentList[2:]
+	}
+
+	l.mu.Lock()
+	l.nextID++
+	t := &task{
+		id:       l.nextID,
+		deadline: time.Now().Add(delay),
+		interval: interval,
+		callback: cb,
+		args:     extra,
+	}
+	l.byID[t.id] = t
+	heap.Push(&l.tasks, t)
+	l.mu.Unlock()
+
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+
+	id, _ := call.Otto.ToValue(t.id)
+	return id
+}
+
+// Defer schedules fn to run on l's driving goroutine at the next
+// opportunity. Unlike the JavaScript-facing timers, fn is a plain Go
+// function rather than an otto.Value, so it is how other Go-side
+// subsystems (such as std.InstallHTTP) hand asynchronous work back to the
+// loop without ever touching the vm from their own goroutine.
+func (l *Loop) Defer(fn func()) {
+	l.mu.Lock()
+	l.nextID++
+	t := &task{id: l.nextID, deadline: time.Now(), goFunc: fn}
+	l.byID[t.id] = t
+	heap.Push(&l.tasks, t)
+	l.mu.Unlock()
+
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Cancel cancels the pending task with the given id, if any. It is safe to
+// cancel a task that has already fired or that does not exist, and safe to
+// call from any goroutine.
+func (l *Loop) Cancel(id int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t, ok := l.byID[id]; ok {
+		t.cancelled = true
+		delete(l.byID, id)
+	}
+}
+
+// Run drives the loop on the calling goroutine until ctx is done. It must be
+// called after Register, and must never be called from more than one
+// goroutine at a time for a given Loop.
+func (l *Loop) Run(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		empty := len(l.tasks) == 0
+		var deadline time.Time
+		if !empty {
+			deadline = l.tasks[0].deadline
+		}
+		l.mu.Unlock()
+
+		if empty {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-l.wake:
+				continue
+			}
+		}
+
+		wait := time.Until(deadline)
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-l.wake:
+				timer.Stop()
+				continue
+			case <-timer.C:
+			}
+		}
+
+		l.mu.Lock()
+		t := heap.Pop(&l.tasks).(*task)
+		cancelled := t.cancelled
+		if !cancelled {
+			if t.interval > 0 {
+				t.deadline = time.Now().Add(t.interval)
+				heap.Push(&l.tasks, t)
+			} else {
+				delete(l.byID, t.id)
+			}
+		}
+		l.mu.Unlock()
+
+		if cancelled {
+			continue
+		}
+
+		if t.goFunc != nil {
+			t.goFunc()
+			continue
+		}
+
+		// Function.call.call(fn, this, ...args) invokes fn with the given
+		// this and arguments. This is the only way to invoke an arbitrary
+		// otto.Value we're holding as if it were a function.
+		callArgs := make([]interface{}, 0, len(t.args)+2)
+		callArgs = append(callArgs, t.callback, otto.UndefinedValue())
+		for _, a := range t.args {
+			callArgs = append(callArgs, a)
+		}
+		if _, err := l.vm.Call("Function.call.call", nil, callArgs...); err != nil {
+			return err
+		}
+	}
+}
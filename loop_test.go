@@ -0,0 +1,81 @@
+package ottomatic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/robertkrimen/otto"
+)
+
+func TestLoop_SetTimeout(t *testing.T) {
+	o := otto.New()
+	loop := NewLoop()
+	if err := loop.Register(o); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.Run(`
+		results = [];
+		setTimeout(function(){ results.push("first") }, 0);
+		setTimeout(function(){ results.push("second") }, 10);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		loop.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	first, err := DeepGet("results.0", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := DeepGet("results.1", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := first.ToString(); s != "first" {
+		t.Errorf("Expected results[0] == \"first\", got %q", s)
+	}
+	if s, _ := second.ToString(); s != "second" {
+		t.Errorf("Expected results[1] == \"second\", got %q", s)
+	}
+}
+
+func TestLoop_ClearTimeout(t *testing.T) {
+	o := otto.New()
+	loop := NewLoop()
+	if err := loop.Register(o); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.Run(`
+		fired = false;
+		id = setTimeout(function(){ fired = true }, 0);
+		clearTimeout(id);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	loop.Run(ctx)
+
+	res, err := DeepGet("fired", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := res.ToBoolean(); b {
+		t.Error("Expected cleared timeout not to fire")
+	}
+}
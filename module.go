@@ -0,0 +1,221 @@
+package ottomatic
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/robertkrimen/otto"
+)
+
+// Resolver resolves a module id to JavaScript source.
+//
+// A Resolver reports ok == false (with an empty source and a nil error)
+// when it does not recognize id, so that a ModuleLoader can fall through to
+// the next resolver in its chain.
+type Resolver interface {
+	Resolve(id string) (src string, ok bool, err error)
+}
+
+// ResolverFunc adapts a plain function to the Resolver interface.
+type ResolverFunc func(id string) (src string, ok bool, err error)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(id string) (string, bool, error) {
+	return f(id)
+}
+
+// FileResolver resolves a module id to a JavaScript file under root. A
+// ".js" extension is appended to id if it does not already have one.
+func FileResolver(root string) Resolver {
+	return ResolverFunc(func(id string) (string, bool, error) {
+		path := id
+		if !strings.HasSuffix(path, ".js") {
+			path += ".js"
+		}
+		data, err := ioutil.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		return string(data), true, nil
+	})
+}
+
+// MapResolver resolves a module id by looking it up in a map of id to
+// JavaScript source. It is mostly useful for tests and for embedding a
+// small number of modules without touching the filesystem.
+func MapResolver(modules map[string]string) Resolver {
+	return ResolverFunc(func(id string) (string, bool, error) {
+		src, ok := modules[id]
+		return src, ok, nil
+	})
+}
+
+// ModuleLoader installs a CommonJS-style require(id) into an *otto.Otto.
+//
+// A module id is first looked up among the Go-backed modules registered
+// with GoModule, and otherwise resolved against the Resolvers supplied to
+// NewModuleLoader, tried in order. A module's exports are cached by resolved id, so requiring the
+// same id twice returns the same value without re-evaluating its source.
+//
+// The zero value is not usable; create a ModuleLoader with NewModuleLoader.
+type ModuleLoader struct {
+	resolvers []Resolver
+	goModules map[string]interface{}
+	cache     map[string]otto.Value
+	loading   map[string]otto.Value
+	transform func(id, src string) (string, error)
+	vm        *otto.Otto
+}
+
+// NewModuleLoader creates a ModuleLoader that resolves JavaScript modules
+// using resolvers, tried in order.
+func NewModuleLoader(resolvers ...Resolver) *ModuleLoader {
+	return &ModuleLoader{
+		resolvers: resolvers,
+		goModules: map[string]interface{}{},
+		cache:     map[string]otto.Value{},
+		loading:   map[string]otto.Value{},
+	}
+}
+
+// GoModule registers v as the module exported under name. A struct v is
+// bound onto the module's exports object exactly as bindStruct binds a
+// struct onto any other object, so `require("k8s").ListPods(...)` calls a
+// bound Go method directly. A non-struct v is exposed as exports.default.
+//
+// GoModule returns l so calls can be chained.
+func (l *ModuleLoader) GoModule(name string, v interface{}) *ModuleLoader {
+	l.goModules[name] = v
+	return l
+}
+
+// WithTransform installs a source transform (for example, for JSX or
+// TypeScript) that is run on a resolved module's source before it is
+// compiled. WithTransform does not apply to Go-backed modules.
+//
+// WithTransform returns l so calls can be chained.
+func (l *ModuleLoader) WithTransform(fn func(id, src string) (string, error)) *ModuleLoader {
+	l.transform = fn
+	return l
+}
+
+// Register installs require(id) into vm, backed by l.
+func (l *ModuleLoader) Register(vm *otto.Otto) error {
+	l.vm = vm
+	return vm.Set("require", l.require)
+}
+
+func (l *ModuleLoader) require(call otto.FunctionCall) otto.Value {
+	id, err := call.Argument(0).ToString()
+	if err != nil {
+		panic(call.Otto.MakeCustomError("Error", err.Error()))
+	}
+	exports, err := l.resolve(id)
+	if err != nil {
+		panic(call.Otto.MakeCustomError("Error", err.Error()))
+	}
+	return exports
+}
+
+// resolve returns the cached or freshly-evaluated exports for id.
+func (l *ModuleLoader) resolve(id string) (otto.Value, error) {
+	if v, ok := l.cache[id]; ok {
+		return v, nil
+	}
+	if v, ok := l.loading[id]; ok {
+		// A require cycle: hand back the exports object as it exists so
+		// far, same as Node's require does.
+		return v, nil
+	}
+
+	if gv, ok := l.goModules[id]; ok {
+		return l.resolveGoModule(id, gv)
+	}
+	return l.resolveSource(id)
+}
+
+func (l *ModuleLoader) resolveGoModule(id string, gv interface{}) (otto.Value, error) {
+	exportsObj, err := l.vm.Object("({})")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+
+	val := reflect.Indirect(reflect.ValueOf(gv))
+	if val.Kind() == reflect.Struct {
+		if err := bindStruct(val, l.vm, exportsObj, nil); err != nil {
+			return otto.UndefinedValue(), err
+		}
+	} else {
+		exportsObj.Set("default", gv)
+	}
+
+	exportsVal := exportsObj.Value()
+	l.cache[id] = exportsVal
+	return exportsVal, nil
+}
+
+func (l *ModuleLoader) resolveSource(id string) (otto.Value, error) {
+	var (
+		src   string
+		found bool
+		err   error
+	)
+	for _, r := range l.resolvers {
+		if src, found, err = r.Resolve(id); err != nil {
+			return otto.UndefinedValue(), err
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		return otto.UndefinedValue(), fmt.Errorf("module %q not found", id)
+	}
+
+	if l.transform != nil {
+		if src, err = l.transform(id, src); err != nil {
+			return otto.UndefinedValue(), err
+		}
+	}
+
+	moduleObj, err := l.vm.Object(`({exports: {}})`)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	exportsVal, err := moduleObj.Get("exports")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	l.loading[id] = exportsVal
+	defer delete(l.loading, id)
+
+	// Wrap the module body exactly as Node does, so top-level `var`
+	// declarations in the module source stay scoped to the module instead
+	// of leaking into the global object.
+	wrapped := "(function(module, exports) {\n" + src + "\n})(module, exports);"
+	if err := l.vm.Set("module", moduleObj); err != nil {
+		return otto.UndefinedValue(), err
+	}
+	if err := l.vm.Set("exports", exportsVal); err != nil {
+		return otto.UndefinedValue(), err
+	}
+	if _, err := l.vm.Run(wrapped); err != nil {
+		return otto.UndefinedValue(), err
+	}
+
+	// The module may have reassigned module.exports, so re-fetch it rather
+	// than trusting the exportsVal we handed out for cycle detection.
+	finalExports, err := moduleObj.Get("exports")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	l.cache[id] = finalExports
+	return finalExports, nil
+}
@@ -0,0 +1,92 @@
+package ottomatic
+
+import (
+	"testing"
+
+	"github.com/robertkrimen/otto"
+)
+
+type MathModule struct {
+	Offset int `otto:"offset"`
+}
+
+func (m *MathModule) Add(a, b int) int {
+	return a + b + m.Offset
+}
+
+func TestModuleLoader_MapResolver(t *testing.T) {
+	o := otto.New()
+	loader := NewModuleLoader(MapResolver(map[string]string{
+		"greet": `module.exports = function(name) { return "hello " + name; };`,
+	}))
+	if err := loader.Register(o); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := o.Run(`require("greet")("world")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := res.ToString(); s != "hello world" {
+		t.Errorf("Expected \"hello world\", got %q", s)
+	}
+}
+
+func TestModuleLoader_Cache(t *testing.T) {
+	o := otto.New()
+	if _, err := o.Run(`calls = 0;`); err != nil {
+		t.Fatal(err)
+	}
+	loader := NewModuleLoader(MapResolver(map[string]string{
+		"counter": `calls = calls + 1; module.exports = calls;`,
+	}))
+	if err := loader.Register(o); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.Run(`a = require("counter"); b = require("counter");`); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := o.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := o.Get("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	av, _ := a.ToInteger()
+	bv, _ := b.ToInteger()
+	if av != 1 || bv != 1 {
+		t.Errorf("Expected both requires to return the cached value 1, got %d and %d", av, bv)
+	}
+}
+
+func TestModuleLoader_GoModule(t *testing.T) {
+	o := otto.New()
+	loader := NewModuleLoader().GoModule("math", &MathModule{Offset: 10})
+	if err := loader.Register(o); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := o.Run(`require("math").Add(1, 2)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.ToInteger(); n != 13 {
+		t.Errorf("Expected 13, got %d", n)
+	}
+}
+
+func TestModuleLoader_NotFound(t *testing.T) {
+	o := otto.New()
+	loader := NewModuleLoader(MapResolver(map[string]string{}))
+	if err := loader.Register(o); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.Run(`require("nosuchmodule")`); err == nil {
+		t.Error("Expected requiring an unresolvable module to throw")
+	}
+}
@@ -28,7 +28,14 @@ NAME is required, and all PARAMs are optional.
 		alternative name. If the field is a pointer, both handles will
 		point to the same object. In any other case, each handle will have
 		its own target value. _More than one alias may be specified._
-	  - `omitempty`: Reserved for future use.
+	  - `omitempty` (example: `otto:"tags,omitempty"`) skips a nil or
+		zero-length slice, array, or map entirely, so no key for it is
+		created in JavaScript.
+	  - `elem=inline` or `elem=raw` control how the elements of a slice,
+		array, or map field are bound. `elem=inline` is the default: each
+		element is registered exactly as it would be on its own, so a
+		struct element is bound per its own 'otto' tags. `elem=raw` skips
+		that recursion and exposes the Go value as-is.
 	  - `returns`, `returns=`, `throws`, and `throws=` reserved for future use.
 
 All unknown params are silently ignored.
@@ -36,6 +43,46 @@ All unknown params are silently ignored.
 If no annotation is specified and the field is exportable (i.e. the Go
 field name starts with an uppercase letter), the field will be exported
 to the JavaScript runtime using its Go name.
+
+BINDING METHODS
+
+In addition to fields, exported methods declared on a struct (or on a pointer
+to that struct) are bound as callable JavaScript functions. Because a method
+declaration cannot carry a struct tag, methods cannot be renamed with the
+`otto` annotation. Instead, Register and its siblings accept an optional
+`map[string]string` that maps a Go method name to the name it should be
+given in JavaScript:
+
+	err := ottomatic.Register("k8s", kubernetes, ottoRuntime, map[string]string{
+		"ListPods": "listPods",
+	})
+
+A method whose final return value is an `error` is treated specially: if
+that error is non-nil, it is surfaced to JavaScript as a thrown error rather
+than as a return value, so `try/catch` behaves the way a JavaScript author
+would expect.
+
+TIMERS
+
+A struct field of type `*Loop` is auto-wired: Register installs
+setTimeout, setInterval, setImmediate, clearTimeout, and clearInterval into
+the runtime, backed by that Loop. See the Loop type for how to drive the
+timers it installs.
+
+MODULES
+
+A ModuleLoader installs a CommonJS-style require(id) into the runtime, and
+can serve modules from the filesystem, from an in-memory map, or straight
+from a Go value via GoModule. See the ModuleLoader type for details.
+
+INTERRUPTION AND TIMEOUTS
+
+Since Register-based applications commonly hand JavaScript to untrusted
+users, RunContext and CallContext wire a context.Context's cancellation
+into otto's own interrupt mechanism, and WithBudget bounds a script by a
+rough operation count instead of (or in addition to) wall-clock time. A
+Loop's Run also takes a context.Context, so timers and callbacks scheduled
+through it honor the same cancellation.
 */
 package ottomatic
 
@@ -43,6 +90,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/robertkrimen/otto"
@@ -55,14 +103,21 @@ import (
 var OttoTagName = "otto"
 
 type ottoTag struct {
-	name    string
-	omit    bool
-	aliases []string
+	name      string
+	omit      bool
+	aliases   []string
+	omitempty bool
+	// elem controls how slice, array, and map elements are bound. It is
+	// either "" (equivalent to "inline"), "inline", or "raw".
+	elem string
 }
 
 // ErrUnsupportedKind indicates that a given kind is not supported by the registry.
 var ErrUnsupportedKind = errors.New("unsupported kind")
 
+// errType is used to detect a trailing `error` return value on a bound method.
+var errType = reflect.TypeOf((*error)(nil)).Elem()
+
 // Undefined is the error version of otto.Value == Undefined.
 //
 // There are a number of situations in which an undefined value in
@@ -86,13 +141,18 @@ type ObjectGetter interface {
 // Register registers v into the JavaScript object o, with the name n.
 //
 // This will attempt to bind v in its entirety. If v is a struct, this will bind
-// it according to the 'ott:' tags on fields.
+// it according to the 'ott:' tags on fields, as well as any exported methods
+// declared on the struct or on a pointer to the struct.
 //
 // Register should be used for root objects.
-func Register(n string, v interface{}, o *otto.Otto) error {
+//
+// methodNames is optional. When provided, its first element is used to rename
+// bound methods from their Go name to a JavaScript name (see "BINDING METHODS"
+// in the package documentation).
+func Register(n string, v interface{}, o *otto.Otto, methodNames ...map[string]string) error {
 	// Here, Otto is an ObjectSetter, so we can bind to the root namespace by
 	// binding directly to the Otto runtime.
-	return RegisterTo(n, v, o, o)
+	return RegisterTo(n, v, o, o, methodNames...)
 }
 
 // RegisterTo registers n to v on the object given in obj.
@@ -103,8 +163,8 @@ func Register(n string, v interface{}, o *otto.Otto) error {
 // This is used to bind a Go value to a non-root JavaScript object. Note that
 // the injection of `obj` into `o` is not handled here. It must be explicitly
 // done via one of Otto's `Set` methods.
-func RegisterTo(n string, v interface{}, o *otto.Otto, obj ObjectSetter) error {
-	return RegisterToAliases(n, v, o, obj, []string{})
+func RegisterTo(n string, v interface{}, o *otto.Otto, obj ObjectSetter, methodNames ...map[string]string) error {
+	return RegisterToAliases(n, v, o, obj, []string{}, methodNames...)
 }
 
 // RegisterToAliases registers n to v on object obj, and then aliases to n.
@@ -117,34 +177,38 @@ func RegisterTo(n string, v interface{}, o *otto.Otto, obj ObjectSetter) error {
 // This is only used when you need to register the same object under multiple
 // JavaScript names, such as when `foo.bar` and `foo.baz` should point to the
 // same thing.
-func RegisterToAliases(n string, v interface{}, o *otto.Otto, obj ObjectSetter, aliases []string) error {
+//
+// methodNames is optional, and, when present, only its first element is used.
+// See Register for its meaning.
+func RegisterToAliases(n string, v interface{}, o *otto.Otto, obj ObjectSetter, aliases []string, methodNames ...map[string]string) error {
+	var mn map[string]string
+	if len(methodNames) > 0 {
+		mn = methodNames[0]
+	}
+
 	val := reflect.Indirect(reflect.ValueOf(v))
 	switch val.Kind() {
 	// TODO: are reflect.Interface, reflect.Ptr, and reflect.Uintptr okay?
 	// TODO: can Complex64/128 be represented by Otto
-	// TODO: is there any processing we need to do on maps, slices, and arrays?
 	case reflect.UnsafePointer, reflect.Chan, reflect.Invalid:
 		return ErrUnsupportedKind
 	case reflect.Struct:
-		s, err := o.Object(n + " = {}")
+		s, err := o.Object("({})")
 		if err != nil {
 			return err
 		}
-		// Handle struct scanning
-		t := val.Type()
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			ot := gettag(&f)
-			iface := val.Field(i).Interface()
-			if !ot.omit {
-				RegisterToAliases(ot.name, iface, o, s, ot.aliases)
-			}
+		if err := bindStruct(val, o, s, mn); err != nil {
+			return err
 		}
 		obj.Set(n, s)
 		for _, a := range aliases {
 			obj.Set(a, s)
 		}
 		return nil
+	case reflect.Slice, reflect.Array:
+		return registerSlice(n, val, o, obj, aliases)
+	case reflect.Map:
+		return registerMap(n, val, o, obj, aliases)
 	default:
 		obj.Set(n, v)
 		for _, a := range aliases {
@@ -154,6 +218,235 @@ func RegisterToAliases(n string, v interface{}, o *otto.Otto, obj ObjectSetter,
 	}
 }
 
+// registerSlice binds a slice or array value as a genuine JavaScript array,
+// binding each element exactly as RegisterToAliases would bind it on its
+// own, so that a struct element is still bound according to its own 'otto'
+// tags.
+func registerSlice(n string, val reflect.Value, o *otto.Otto, obj ObjectSetter, aliases []string) error {
+	arr, err := o.Object("([])")
+	if err != nil {
+		return err
+	}
+	for i := 0; i < val.Len(); i++ {
+		if err := RegisterToAliases(strconv.Itoa(i), val.Index(i).Interface(), o, arr, nil); err != nil {
+			return err
+		}
+	}
+	obj.Set(n, arr)
+	for _, a := range aliases {
+		obj.Set(a, arr)
+	}
+	return nil
+}
+
+// registerMap binds a map value as a genuine JavaScript object, binding each
+// entry exactly as RegisterToAliases would bind it on its own. Only maps
+// with string keys are supported.
+func registerMap(n string, val reflect.Value, o *otto.Otto, obj ObjectSetter, aliases []string) error {
+	if val.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("map key type %s is not supported; only string-keyed maps are", val.Type().Key())
+	}
+	m, err := o.Object("({})")
+	if err != nil {
+		return err
+	}
+	for _, k := range val.MapKeys() {
+		if err := RegisterToAliases(k.String(), val.MapIndex(k).Interface(), o, m, nil); err != nil {
+			return err
+		}
+	}
+	obj.Set(n, m)
+	for _, a := range aliases {
+		obj.Set(a, m)
+	}
+	return nil
+}
+
+// bindStruct binds each field and each exported method of val onto target.
+// val must be a struct (not a pointer to one); methodNames is forwarded to
+// bindMethods unchanged. This is the shared implementation behind the
+// reflect.Struct case of RegisterToAliases, and is also used to bind a Go
+// struct directly onto an existing JavaScript object (for example, a
+// CommonJS module's exports object).
+func bindStruct(val reflect.Value, o *otto.Otto, target ObjectSetter, methodNames map[string]string) error {
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		ot := gettag(&f)
+		if ot.omit {
+			continue
+		}
+		fv := val.Field(i)
+		iface := fv.Interface()
+		if loop, ok := iface.(*Loop); ok {
+			// A *Loop field is auto-wired: it installs the timer globals
+			// rather than being bound as an ordinary object.
+			loop.Register(o)
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			if ot.omitempty && fv.Len() == 0 {
+				continue
+			}
+			if ot.elem == "raw" {
+				target.Set(ot.name, iface)
+				for _, a := range ot.aliases {
+					target.Set(a, iface)
+				}
+				continue
+			}
+		}
+		if err := RegisterToAliases(ot.name, iface, o, target, ot.aliases); err != nil {
+			return err
+		}
+	}
+
+	// Handle methods declared on the struct. Bind from a pointer so that
+	// both value- and pointer-receiver methods are found: val's own address
+	// when it is addressable (which it will be whenever the original value
+	// was a pointer), or else a pointer to a copy of val.
+	methodVal := val
+	if val.CanAddr() {
+		methodVal = val.Addr()
+	} else {
+		methodVal = reflect.New(val.Type())
+		methodVal.Elem().Set(val)
+	}
+	bindMethods(methodVal, o, target, methodNames)
+	return nil
+}
+
+// bindMethods binds each exported method of rv's type as a callable JavaScript
+// function on obj, applying any renames given in methodNames.
+func bindMethods(rv reflect.Value, o *otto.Otto, obj ObjectSetter, methodNames map[string]string) {
+	t := rv.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if m.PkgPath != "" {
+			// Unexported method.
+			continue
+		}
+		name := m.Name
+		if alt, ok := methodNames[m.Name]; ok {
+			name = alt
+		}
+		obj.Set(name, wrapMethod(rv.Method(i)))
+	}
+}
+
+// wrapMethod adapts a bound Go method (via reflect.Value.Method) into a
+// function otto can call from JavaScript.
+//
+// Arguments are converted with otto.Value.Export, and converted to the
+// method's parameter types where necessary; an argument that can't be
+// assigned or converted to its parameter type (for example, an object
+// passed where a scalar is expected) is reported as a thrown JavaScript
+// error rather than being passed through mistyped. If the method's final
+// return value is a non-nil error, it is panicked as a JavaScript Error
+// value (made with otto's MakeCustomError) so that
+// it surfaces to JavaScript as a thrown exception rather than as a return
+// value; otherwise the first remaining return value (if any) is returned
+// to the caller. A panic from the method itself (as opposed to one of the
+// cases above) is also recovered and reported as a thrown JavaScript
+// error, so that invoking a bound method can never crash the host
+// process.
+func wrapMethod(fn reflect.Value) func(otto.FunctionCall) otto.Value {
+	ft := fn.Type()
+	fixed := ft.NumIn()
+	variadic := ft.IsVariadic()
+	if variadic {
+		fixed--
+	}
+
+	return func(call otto.FunctionCall) otto.Value {
+		vm := call.Otto
+		args := call.ArgumentList
+
+		n := fixed
+		if variadic && len(args) > fixed {
+			n = len(args)
+		}
+
+		in := make([]reflect.Value, n)
+		for i := 0; i < n; i++ {
+			var paramType reflect.Type
+			if variadic && i >= fixed {
+				paramType = ft.In(fixed).Elem()
+			} else {
+				paramType = ft.In(i)
+			}
+			if i >= len(args) {
+				in[i] = reflect.Zero(paramType)
+				continue
+			}
+			exported, err := args[i].Export()
+			if err != nil {
+				panic(vm.MakeCustomError("Error", err.Error()))
+			}
+			in[i] = convertArg(vm, exported, paramType)
+		}
+
+		out := callMethod(vm, fn, in)
+
+		if n := len(out); n > 0 && out[n-1].Type() == errType {
+			if !out[n-1].IsNil() {
+				panic(vm.MakeCustomError("Error", out[n-1].Interface().(error).Error()))
+			}
+			out = out[:n-1]
+		}
+
+		if len(out) == 0 {
+			return otto.UndefinedValue()
+		}
+
+		res, err := vm.ToValue(out[0].Interface())
+		if err != nil {
+			panic(vm.MakeCustomError("Error", err.Error()))
+		}
+		return res
+	}
+}
+
+// convertArg converts exported (the result of otto.Value.Export) to
+// paramType, or panics an otto.Error if it cannot be assigned or converted.
+// A JS null/undefined exports as a nil interface{}, which convertArg maps
+// to paramType's zero value rather than an invalid reflect.Value.
+func convertArg(vm *otto.Otto, exported interface{}, paramType reflect.Type) reflect.Value {
+	if exported == nil {
+		return reflect.Zero(paramType)
+	}
+	argVal := reflect.ValueOf(exported)
+	if argVal.Type().AssignableTo(paramType) {
+		return argVal
+	}
+	// reflect.Type.ConvertibleTo allows numeric-to-string conversions
+	// (interpreting the number as a rune), which is never what a caller
+	// means when passing a JS number where a bound method expects a
+	// string; reject that case explicitly rather than silently producing
+	// a one-character garbage string.
+	numericToString := paramType.Kind() == reflect.String && argVal.Kind() != reflect.String
+	if !numericToString && argVal.Type().ConvertibleTo(paramType) {
+		return argVal.Convert(paramType)
+	}
+	panic(vm.MakeCustomError("Error", fmt.Sprintf("cannot use %s as %s", argVal.Type(), paramType)))
+}
+
+// callMethod invokes fn with in, recovering any panic raised by the method
+// itself (as opposed to one raised by convertArg above) and reporting it as
+// a thrown JavaScript error instead of letting it escape to the host.
+func callMethod(vm *otto.Otto, fn reflect.Value, in []reflect.Value) (out []reflect.Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ottoErr, ok := r.(otto.Value); ok {
+				panic(ottoErr)
+			}
+			panic(vm.MakeCustomError("Error", fmt.Sprintf("%v", r)))
+		}
+	}()
+	return fn.Call(in)
+}
+
 func gettag(field *reflect.StructField) ottoTag {
 	t := field.Tag.Get(OttoTagName)
 	if len(t) == 0 {
@@ -176,6 +469,10 @@ func gettag(field *reflect.StructField) ottoTag {
 		switch item := k; {
 		case strings.HasPrefix(item, "alias="):
 			ot.aliases = append(ot.aliases, strings.TrimPrefix(item, "alias="))
+		case item == "omitempty":
+			ot.omitempty = true
+		case strings.HasPrefix(item, "elem="):
+			ot.elem = strings.TrimPrefix(item, "elem=")
 		}
 	}
 	return ot
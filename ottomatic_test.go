@@ -1,6 +1,8 @@
 package ottomatic
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/robertkrimen/otto"
@@ -20,6 +22,26 @@ type InnerObject struct {
 	Value int `otto:"value"`
 }
 
+type Greeter struct {
+	Greeting string `otto:"greeting"`
+}
+
+func (g *Greeter) Hello(name string) string {
+	return g.Greeting + ", " + name
+}
+
+func (g *Greeter) Fail() (string, error) {
+	return "", errors.New("always fails")
+}
+
+func (g *Greeter) Panic() string {
+	panic("boom")
+}
+
+func (g *Greeter) Join(sep string, parts ...string) string {
+	return strings.Join(parts, sep)
+}
+
 func TestDeepGet(t *testing.T) {
 	o := otto.New()
 	if _, err := o.Run(`parent = { child: {grandchild: "hello"}};`); err != nil {
@@ -183,3 +205,61 @@ func TestRegister_Struct(t *testing.T) {
 		t.Errorf("Expected 31, got %s (%s)", ival, err)
 	}
 }
+
+func TestRegister_Methods(t *testing.T) {
+	o := otto.New()
+	g := &Greeter{Greeting: "Hi"}
+
+	if err := Register("greeter", g, o, map[string]string{"Hello": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := o.Run(`greeter.hello("world")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := res.ToString(); err != nil || s != "Hi, world" {
+		t.Errorf("Expected \"Hi, world\", got %q (%s)", s, err)
+	}
+
+	// A method's trailing error return should be raised as a thrown
+	// JavaScript error, not returned as a value.
+	_, err = o.Run(`greeter.Fail()`)
+	if err == nil {
+		t.Error("Expected greeter.Fail() to throw")
+	}
+
+	// A null/undefined argument must not crash the process; it should
+	// bind as the parameter's zero value.
+	res, err = o.Run(`greeter.hello(null)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := res.ToString(); err != nil || s != "Hi, " {
+		t.Errorf("Expected \"Hi, \", got %q (%s)", s, err)
+	}
+
+	// An argument that can't be converted to its parameter type must throw
+	// a JavaScript error instead of crashing the process.
+	if _, err = o.Run(`greeter.hello(42)`); err == nil {
+		t.Error("Expected greeter.hello(42) to throw")
+	}
+	if _, err = o.Run(`greeter.hello({})`); err == nil {
+		t.Error("Expected greeter.hello({}) to throw")
+	}
+
+	// A panic raised by the method itself must be recovered and surfaced
+	// as a thrown JavaScript error, not crash the process.
+	if _, err = o.Run(`greeter.Panic()`); err == nil {
+		t.Error("Expected greeter.Panic() to throw")
+	}
+
+	// Variadic methods should bind their trailing arguments individually.
+	res, err = o.Run(`greeter.Join("-", "a", "b", "c")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := res.ToString(); err != nil || s != "a-b-c" {
+		t.Errorf("Expected \"a-b-c\", got %q (%s)", s, err)
+	}
+}
@@ -0,0 +1,134 @@
+// Package std offers one-call installers for the bindings most otto users
+// end up hand-rolling: console, Go io.Writer/io.Reader wrappers, a JSON
+// install that can stringify bound Go values, and a fetch-style HTTP
+// client. Each installer takes a *otto.Otto and composes with
+// ottomatic.Register, so a caller can still override any individual
+// binding on a per-vm basis.
+package std
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/robertkrimen/otto"
+)
+
+// InstallConsole installs a console object into vm that writes to w,
+// mirroring the subset of the browser/Node console API otto users reach
+// for most often: log, info, warn, error, and debug.
+//
+// When a call's first argument is a string containing a '%' verb, the
+// remaining arguments are used as Sprintf-style substitutions. Otherwise,
+// all arguments are space-joined the way console.log joins them in Node.
+//
+// otto exports a JS number as whichever of int64 or float64 happens to
+// match how it was produced (a bare literal like 5 exports as int64, while
+// -2 or 1e3 export as float64), so an argument can arrive as the "wrong"
+// Go type for the verb it's bound to: a float64 against %d formats as
+// "%!d(float64=5)", and an int64 against %f formats as "%!f(int64=5)".
+// consoleFormat coerces a substitution to whichever of those two types the
+// verb it's bound to actually expects, so numeric verbs format the way
+// script authors expect regardless of which representation otto happened
+// to pick.
+func InstallConsole(vm *otto.Otto, w io.Writer) error {
+	console, err := vm.Object("({})")
+	if err != nil {
+		return err
+	}
+	for _, level := range []string{"log", "info", "warn", "error", "debug"} {
+		if err := console.Set(level, func(call otto.FunctionCall) otto.Value {
+			fmt.Fprintln(w, consoleFormat(call.ArgumentList))
+			return otto.UndefinedValue()
+		}); err != nil {
+			return err
+		}
+	}
+	return vm.Set("console", console)
+}
+
+func consoleFormat(args []otto.Value) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	if first, err := args[0].ToString(); err == nil && strings.Contains(first, "%") && len(args) > 1 {
+		verbs := formatVerbs(first)
+		rest := make([]interface{}, 0, len(args)-1)
+		for i, a := range args[1:] {
+			if exported, err := a.Export(); err == nil {
+				var verb byte
+				if i < len(verbs) {
+					verb = verbs[i]
+				}
+				rest = append(rest, coerceNumericVerbArg(exported, verb))
+			} else {
+				rest = append(rest, a.String())
+			}
+		}
+		return fmt.Sprintf(first, rest...)
+	}
+
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// coerceNumericVerbArg converts exported to whichever of int64 or float64
+// verb expects, when that conversion is exact: a whole-number float64
+// against an integer verb becomes int64, and an int64 against a float verb
+// becomes float64. Anything else (a non-integer float against an integer
+// verb, or any non-numeric type) is returned unchanged, since there's
+// nothing sensible to coerce.
+func coerceNumericVerbArg(exported interface{}, verb byte) interface{} {
+	switch v := exported.(type) {
+	case float64:
+		if isIntegerVerb(verb) && v == float64(int64(v)) {
+			return int64(v)
+		}
+	case int64:
+		if isFloatVerb(verb) {
+			return float64(v)
+		}
+	}
+	return exported
+}
+
+// formatVerbRe matches a single fmt verb directive: '%', optional flags,
+// optional width/precision, and the verb letter (or a second '%' for the
+// "%%" literal escape).
+var formatVerbRe = regexp.MustCompile(`%[-+ #0]*[0-9]*(?:\.[0-9]*)?[a-zA-Z%]`)
+
+// formatVerbs returns the verb letter fmt will bind each successive
+// substitution argument to when formatting format, in order; "%%" (which
+// consumes no argument) is skipped.
+func formatVerbs(format string) []byte {
+	var verbs []byte
+	for _, m := range formatVerbRe.FindAllString(format, -1) {
+		verb := m[len(m)-1]
+		if verb == '%' {
+			continue
+		}
+		verbs = append(verbs, verb)
+	}
+	return verbs
+}
+
+// integerVerbs and floatVerbs are the fmt verbs that format their argument
+// as an integer or a float, respectively; coerceNumericVerbArg uses these
+// to decide which way (if any) to convert a numeric substitution.
+const (
+	integerVerbs = "bcdoOqxXU"
+	floatVerbs   = "eEfFgG"
+)
+
+func isIntegerVerb(verb byte) bool {
+	return strings.IndexByte(integerVerbs, verb) >= 0
+}
+
+func isFloatVerb(verb byte) bool {
+	return strings.IndexByte(floatVerbs, verb) >= 0
+}
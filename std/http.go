@@ -0,0 +1,175 @@
+package std
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/robertkrimen/otto"
+
+	"github.com/technosophos/ottomatic"
+)
+
+// InstallHTTP installs a fetch(url, opts) function into vm, backed by
+// client. opts is optional; when given, its method and body properties are
+// honored (headers are not yet supported).
+//
+// fetch returns a promise-like object exposing .then(onFulfilled) and
+// .catch(onRejected). The request runs on its own goroutine, and its
+// callback is handed back to loop via Loop.Defer so it only ever runs from
+// loop's driving goroutine, alongside any setTimeout/setInterval callbacks
+// that vm has registered through the same loop; callers must likewise only
+// touch vm from that same goroutine once Run is driving it. then/catch may
+// be registered before or after the request completes (registering them is
+// safe from any goroutine, since fetchCall guards the shared result with a
+// mutex), and whichever callback applies fires exactly once, through
+// loop.Defer, once both the result and that callback are available.
+func InstallHTTP(vm *otto.Otto, client *http.Client, loop *ottomatic.Loop) error {
+	return vm.Set("fetch", func(call otto.FunctionCall) otto.Value {
+		url, _ := call.Argument(0).ToString()
+		method, body := fetchOpts(call.Argument(1))
+
+		promise, err := call.Otto.Object("({})")
+		if err != nil {
+			panic(call.Otto.MakeCustomError("Error", err.Error()))
+		}
+
+		fc := &fetchCall{vm: vm, loop: loop}
+		promise.Set("then", func(c2 otto.FunctionCall) otto.Value {
+			fc.setOnFulfilled(c2.Argument(0))
+			return promise.Value()
+		})
+		promise.Set("catch", func(c2 otto.FunctionCall) otto.Value {
+			fc.setOnRejected(c2.Argument(0))
+			return promise.Value()
+		})
+
+		req, reqErr := http.NewRequest(method, url, body)
+		go func() {
+			status, payload, fetchErr := doFetch(client, req, reqErr)
+			fc.resolve(status, payload, fetchErr)
+		}()
+
+		return promise.Value()
+	})
+}
+
+// fetchCall tracks the state of a single in-flight fetch(): the otto
+// callbacks registered via .then/.catch, and the result of the request once
+// it completes. Both the registering goroutine (wherever .then/.catch is
+// called from) and the goroutine running the request access this state, so
+// mu guards all of it. Whichever of resolve/setOnFulfilled/setOnRejected
+// observes both the result and the matching callback schedules the single
+// call into the callback through loop.Defer, so the vm is only ever touched
+// from loop's driving goroutine and the result is never silently dropped
+// regardless of registration order.
+type fetchCall struct {
+	vm   *otto.Otto
+	loop *ottomatic.Loop
+
+	mu          sync.Mutex
+	resolved    bool
+	status      int
+	payload     string
+	fetchErr    error
+	onFulfilled otto.Value
+	onRejected  otto.Value
+}
+
+func (fc *fetchCall) setOnFulfilled(fn otto.Value) {
+	fc.mu.Lock()
+	fc.onFulfilled = fn
+	ready := fc.resolved && fc.fetchErr == nil
+	status, payload := fc.status, fc.payload
+	fc.mu.Unlock()
+
+	if ready {
+		fc.loop.Defer(func() { fc.callFulfilled(fn, status, payload) })
+	}
+}
+
+func (fc *fetchCall) setOnRejected(fn otto.Value) {
+	fc.mu.Lock()
+	fc.onRejected = fn
+	ready := fc.resolved && fc.fetchErr != nil
+	fetchErr := fc.fetchErr
+	fc.mu.Unlock()
+
+	if ready {
+		fc.loop.Defer(func() { fc.callRejected(fn, fetchErr) })
+	}
+}
+
+func (fc *fetchCall) resolve(status int, payload string, fetchErr error) {
+	fc.mu.Lock()
+	fc.resolved = true
+	fc.status, fc.payload, fc.fetchErr = status, payload, fetchErr
+	onFulfilled, onRejected := fc.onFulfilled, fc.onRejected
+	fc.mu.Unlock()
+
+	fc.loop.Defer(func() {
+		if fetchErr != nil {
+			fc.callRejected(onRejected, fetchErr)
+			return
+		}
+		fc.callFulfilled(onFulfilled, status, payload)
+	})
+}
+
+func (fc *fetchCall) callFulfilled(fn otto.Value, status int, payload string) {
+	if !fn.IsFunction() {
+		return
+	}
+	result, err := fc.vm.Object("({})")
+	if err != nil {
+		return
+	}
+	result.Set("status", status)
+	result.Set("text", payload)
+	fn.Call(otto.Value{}, result.Value())
+}
+
+func (fc *fetchCall) callRejected(fn otto.Value, fetchErr error) {
+	if !fn.IsFunction() {
+		return
+	}
+	errVal, _ := fc.vm.ToValue(fetchErr.Error())
+	fn.Call(otto.Value{}, errVal)
+}
+
+func fetchOpts(opts otto.Value) (method string, body *strings.Reader) {
+	method = "GET"
+	body = strings.NewReader("")
+	if !opts.IsObject() {
+		return method, body
+	}
+	obj := opts.Object()
+	if m, err := obj.Get("method"); err == nil {
+		if s, err := m.ToString(); err == nil && m.IsString() {
+			method = s
+		}
+	}
+	if b, err := obj.Get("body"); err == nil && b.IsString() {
+		if s, err := b.ToString(); err == nil {
+			body = strings.NewReader(s)
+		}
+	}
+	return method, body
+}
+
+func doFetch(client *http.Client, req *http.Request, reqErr error) (status int, payload string, err error) {
+	if reqErr != nil {
+		return 0, "", reqErr
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(data), nil
+}
@@ -0,0 +1,37 @@
+package std
+
+import (
+	"encoding/json"
+
+	"github.com/robertkrimen/otto"
+)
+
+// InstallJSON replaces vm's JSON.stringify with one backed by Export and
+// encoding/json, so that a Go value bound as an opaque "native" otto value
+// (for example, a slice or map field Set directly rather than through
+// ottomatic.Register) round-trips through JSON the way a plain JavaScript
+// object would.
+//
+// This installer trades away support for JSON.stringify's replacer and
+// indent arguments; a caller that needs those should not use InstallJSON.
+func InstallJSON(vm *otto.Otto) error {
+	jsonObj, err := vm.Object("JSON")
+	if err != nil {
+		return err
+	}
+	return jsonObj.Set("stringify", func(call otto.FunctionCall) otto.Value {
+		exported, err := call.Argument(0).Export()
+		if err != nil {
+			panic(call.Otto.MakeCustomError("Error", err.Error()))
+		}
+		data, err := json.Marshal(exported)
+		if err != nil {
+			panic(call.Otto.MakeCustomError("Error", err.Error()))
+		}
+		result, err := call.Otto.ToValue(string(data))
+		if err != nil {
+			panic(call.Otto.MakeCustomError("Error", err.Error()))
+		}
+		return result
+	})
+}
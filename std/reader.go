@@ -0,0 +1,44 @@
+package std
+
+import (
+	"io"
+
+	"github.com/robertkrimen/otto"
+)
+
+// defaultReadSize is used when name.read(n) is called with n <= 0.
+const defaultReadSize = 4096
+
+// InstallReader installs a JavaScript object called name into vm that wraps
+// r. Calling name.read(n) reads up to n bytes from r and returns
+// {data, eof, error}.
+func InstallReader(vm *otto.Otto, name string, r io.Reader) error {
+	obj, err := vm.Object("({})")
+	if err != nil {
+		return err
+	}
+	if err := obj.Set("read", func(call otto.FunctionCall) otto.Value {
+		n, _ := call.Argument(0).ToInteger()
+		if n <= 0 {
+			n = defaultReadSize
+		}
+		buf := make([]byte, n)
+		read, err := r.Read(buf)
+
+		result, objErr := call.Otto.Object("({})")
+		if objErr != nil {
+			panic(call.Otto.MakeCustomError("Error", objErr.Error()))
+		}
+		result.Set("data", string(buf[:read]))
+		result.Set("eof", err == io.EOF)
+		if err != nil && err != io.EOF {
+			result.Set("error", err.Error())
+		} else {
+			result.Set("error", "")
+		}
+		return result.Value()
+	}); err != nil {
+		return err
+	}
+	return vm.Set(name, obj)
+}
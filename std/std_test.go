@@ -0,0 +1,191 @@
+package std
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robertkrimen/otto"
+
+	"github.com/technosophos/ottomatic"
+)
+
+func TestInstallConsole(t *testing.T) {
+	o := otto.New()
+	var buf bytes.Buffer
+	if err := InstallConsole(o, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.Run(`console.log("hello", "world"); console.warn("got %s", "here");`); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "hello world") {
+		t.Errorf("Expected log output to contain \"hello world\", got %q", out)
+	}
+	if !strings.Contains(out, "got here") {
+		t.Errorf("Expected formatted warn output to contain \"got here\", got %q", out)
+	}
+}
+
+func TestInstallConsole_NumericVerb(t *testing.T) {
+	o := otto.New()
+	var buf bytes.Buffer
+	if err := InstallConsole(o, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// otto may export a JS number as int64 or float64; console.log should
+	// format %d as an integer either way rather than emitting
+	// "%!d(float64=5)".
+	if _, err := o.Run(`console.log("got %d items", 5);`); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "got 5 items") {
+		t.Errorf("Expected formatted output to contain \"got 5 items\", got %q", out)
+	}
+}
+
+func TestInstallConsole_FloatVerb(t *testing.T) {
+	o := otto.New()
+	var buf bytes.Buffer
+	if err := InstallConsole(o, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// A whole-number value against a float verb must still format as a
+	// float (e.g. "3.000000"), not be coerced to an integer just because
+	// %d elsewhere needs that coercion.
+	if _, err := o.Run(`console.log("%f", 3);`); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "3.000000") {
+		t.Errorf("Expected formatted output to contain \"3.000000\", got %q", out)
+	}
+}
+
+func TestInstallWriterAndReader(t *testing.T) {
+	o := otto.New()
+	var buf bytes.Buffer
+	if err := InstallWriter(o, "out", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := InstallReader(o, "reader", strings.NewReader("hi there")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := o.Run(`out.write("payload")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := res.Object().Get("len"); err != nil || mustInt(t, n) != 7 {
+		t.Errorf("Expected len 7, got %v (%s)", n, err)
+	}
+	if buf.String() != "payload" {
+		t.Errorf("Expected buffer to contain \"payload\", got %q", buf.String())
+	}
+
+	res, err = o.Run(`reader.read(2)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, err := res.Object().Get("data"); err != nil || mustString(t, d) != "hi" {
+		t.Errorf("Expected data \"hi\", got %v (%s)", d, err)
+	}
+}
+
+func mustInt(t *testing.T, v otto.Value) int64 {
+	t.Helper()
+	n, err := v.ToInteger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func mustString(t *testing.T, v otto.Value) string {
+	t.Helper()
+	s, err := v.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestInstallJSON(t *testing.T) {
+	o := otto.New()
+	if err := InstallJSON(o); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := o.Run(`JSON.stringify({a: 1, b: "two"})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := mustString(t, res); s != `{"a":1,"b":"two"}` {
+		t.Errorf("Expected round-tripped JSON, got %q", s)
+	}
+}
+
+func TestInstallHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	o := otto.New()
+	loop := ottomatic.NewLoop()
+	if err := loop.Register(o); err != nil {
+		t.Fatal(err)
+	}
+	if err := InstallHTTP(o, srv.Client(), loop); err != nil {
+		t.Fatal(err)
+	}
+
+	// The vm must only be touched from loop's driving goroutine once Run
+	// is underway, so report the fulfilled callback's result through a Go
+	// channel rather than reading a JS global back from this goroutine.
+	status := make(chan int64, 1)
+	if err := o.Set("__report", func(call otto.FunctionCall) otto.Value {
+		n, _ := call.Argument(0).ToInteger()
+		status <- n
+		return otto.UndefinedValue()
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.Run(`
+		fetch("` + srv.URL + `").then(function(res){ __report(res.status); });
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		loop.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case n := <-status:
+		if n != 404 {
+			t.Errorf("Expected status 404 from the default handler, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for fetch to resolve")
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,40 @@
+package std
+
+import (
+	"io"
+
+	"github.com/robertkrimen/otto"
+)
+
+// InstallWriter installs a JavaScript object called name into vm that wraps
+// w. Calling name.write(data) writes data, coerced to a string, to w and
+// returns {len, error}: the number of bytes written, and an error string
+// that is empty on success.
+func InstallWriter(vm *otto.Otto, name string, w io.Writer) error {
+	obj, err := vm.Object("({})")
+	if err != nil {
+		return err
+	}
+	if err := obj.Set("write", func(call otto.FunctionCall) otto.Value {
+		data, _ := call.Argument(0).ToString()
+		n, err := w.Write([]byte(data))
+		return writeResult(call.Otto, n, err)
+	}); err != nil {
+		return err
+	}
+	return vm.Set(name, obj)
+}
+
+func writeResult(vm *otto.Otto, n int, err error) otto.Value {
+	result, objErr := vm.Object("({})")
+	if objErr != nil {
+		panic(vm.MakeCustomError("Error", objErr.Error()))
+	}
+	result.Set("len", n)
+	if err != nil {
+		result.Set("error", err.Error())
+	} else {
+		result.Set("error", "")
+	}
+	return result.Value()
+}